@@ -0,0 +1,70 @@
+package geoip
+
+import "fmt"
+
+// ErrorCategory classifies why OpenDb/OpenDbContext failed to open a
+// particular database file, or, for ErrWrongType, why a lookup method
+// couldn't use the database it has open.
+type ErrorCategory int
+
+const (
+	// ErrNotFound means the file doesn't exist, or os.Stat failed on it for
+	// a reason other than permissions.
+	ErrNotFound ErrorCategory = iota
+	// ErrPermission means the file exists but couldn't be read.
+	ErrPermission
+	// ErrCorrupt means libgeoip couldn't parse the file as a GeoIP database.
+	ErrCorrupt
+	// ErrWrongType means the file opened but isn't the database type the
+	// caller needed.
+	ErrWrongType
+)
+
+func (c ErrorCategory) String() string {
+	switch c {
+	case ErrNotFound:
+		return "not found"
+	case ErrPermission:
+		return "permission denied"
+	case ErrCorrupt:
+		return "corrupt database"
+	case ErrWrongType:
+		return "wrong database type"
+	default:
+		return "unknown error"
+	}
+}
+
+// Error reports why opening a single GeoIP database file failed. OpenDb and
+// OpenDbContext join one of these per file they couldn't open into the
+// error they return, so callers can inspect exactly which paths failed and
+// why instead of parsing a combined string. Lookup methods also return one,
+// with Category ErrWrongType, when the open database is the wrong edition.
+type Error struct {
+	File     string
+	Category ErrorCategory
+	// Err is the underlying error, if any: the os.Stat error, or the errno
+	// C.GeoIP_open returned.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("geoip: %s: %s: %s", e.File, e.Category, e.Err)
+	}
+	return fmt.Sprintf("geoip: %s: %s", e.File, e.Category)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrongTypeErr builds the error a lookup method returns when gi's database
+// opened fine but isn't the edition that method needs.
+func (gi *GeoIP) wrongTypeErr() error {
+	var file string
+	if len(gi.files) > 0 {
+		file = gi.files[0]
+	}
+	return &Error{File: file, Category: ErrWrongType}
+}
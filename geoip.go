@@ -13,24 +13,41 @@ package geoip
 import "C"
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net"
 	"os"
 	"runtime"
 	"sync"
 	"unsafe"
+
+	"github.com/horgh/geoip/record"
 )
 
 type GeoIP struct {
 	db *C.GeoIP
 
+	// files holds the paths gi was opened from, if any. It's used by
+	// Watch/OpenWithReload to reopen the database on change.
+	files []string
+
 	// We don't use GeoIP's thread-safe API calls, which means there is a
 	// single global netmask variable that gets clobbered in the main
 	// lookup routine.  Any calls which have _GeoIP_seek_record_gl need to
 	// be wrapped in this mutex.
 
 	mu sync.Mutex
+
+	// watchMu guards watchStop; watchWG tracks the watcher goroutine so
+	// Close can wait for it to exit.
+	watchMu   sync.Mutex
+	watchStop chan struct{}
+	watchWG   sync.WaitGroup
+
+	// reservedRanges and reservedSet back SetReservedRanges; see reserved.go.
+	reservedRanges []*net.IPNet
+	reservedSet    bool
 }
 
 func (gi *GeoIP) free() {
@@ -57,6 +74,19 @@ func Open(files ...string) (*GeoIP, error) {
 // If you don't pass a filename, it will try opening the database from
 // a list of common paths.
 func OpenDb(files []string, flag int) (*GeoIP, error) {
+	return OpenDbContext(context.Background(), files, flag)
+}
+
+// OpenDbContext is the same as OpenDb but ctx can cancel the open, which
+// matters if a database lives on a slow or remote-mounted filesystem: the
+// stat and the cgo open of each candidate file run on their own goroutine
+// so a hang on one doesn't block ctx from interrupting the call, not just
+// the gap between candidates.
+//
+// If none of files can be opened, the returned error is an errors.Join of
+// one *Error per file attempted, so callers can inspect exactly which
+// paths failed and why.
+func OpenDbContext(ctx context.Context, files []string, flag int) (*GeoIP, error) {
 	if len(files) == 0 {
 		files = []string{
 			"/usr/share/GeoIP/GeoIP.dat",       // Linux default
@@ -70,38 +100,91 @@ func OpenDb(files []string, flag int) (*GeoIP, error) {
 	g := &GeoIP{}
 	runtime.SetFinalizer(g, (*GeoIP).free)
 
-	var err error
+	var errs []error
 
 	for _, file := range files {
-
-		// libgeoip prints errors if it can't open the file, so check first
-		if _, err := os.Stat(file); err != nil {
-			if os.IsExist(err) {
-				log.Println(err)
-			}
+		db, fileErr, ctxErr := openFileContext(ctx, file, flag)
+		if ctxErr != nil {
+			return nil, ctxErr
+		}
+		if fileErr != nil {
+			errs = append(errs, fileErr)
 			continue
 		}
 
-		cbase := C.CString(file)
-		defer C.free(unsafe.Pointer(cbase))
-
-		g.db, err = C.GeoIP_open(cbase, C.int(flag))
-		if g.db != nil && err != nil {
-			break
-		}
-	}
-	if err != nil {
-		return nil, fmt.Errorf("Error opening GeoIP database (%s): %s", files, err)
+		g.db = db
+		g.files = []string{file}
+		break
 	}
 
 	if g.db == nil {
-		return nil, fmt.Errorf("Didn't open GeoIP database (%s)", files)
+		return nil, errors.Join(errs...)
 	}
 
 	C.GeoIP_set_charset(g.db, C.GEOIP_CHARSET_UTF8)
 	return g, nil
 }
 
+// openFileContext stats and opens file, returning either a handle, or a
+// nil handle plus the *Error describing why it couldn't be used. If ctx
+// ends before either finishes, it instead returns a nil handle and ctx's
+// own error in ctxErr.
+//
+// The stat and the cgo open run on their own goroutine so that a hang on
+// one (for example a stuck NFS mount) doesn't keep ctx from interrupting
+// the call. If ctx ends first, that goroutine keeps running in the
+// background; if it does eventually succeed, its handle is freed rather
+// than leaked.
+func openFileContext(ctx context.Context, file string, flag int) (db *C.GeoIP, fileErr *Error, ctxErr error) {
+	type result struct {
+		db  *C.GeoIP
+		err *Error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		if _, statErr := os.Stat(file); statErr != nil {
+			done <- result{err: &Error{File: file, Category: statErrorCategory(statErr), Err: statErr}}
+			return
+		}
+
+		cbase := C.CString(file)
+		db, err := C.GeoIP_open(cbase, C.int(flag))
+		C.free(unsafe.Pointer(cbase))
+
+		if db == nil {
+			done <- result{err: &Error{File: file, Category: ErrCorrupt, Err: err}}
+			return
+		}
+
+		done <- result{db: db}
+	}()
+
+	select {
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.db != nil {
+				C.GeoIP_delete(r.db)
+			}
+		}()
+		return nil, nil, ctx.Err()
+	case r := <-done:
+		return r.db, r.err, nil
+	}
+}
+
+// statErrorCategory maps an os.Stat error to an ErrorCategory.
+func statErrorCategory(err error) ErrorCategory {
+	switch {
+	case os.IsNotExist(err):
+		return ErrNotFound
+	case os.IsPermission(err):
+		return ErrPermission
+	default:
+		return ErrNotFound
+	}
+}
+
 // SetCustomDirectory sets the default location for the GeoIP .dat files used when
 // calling OpenType()
 func SetCustomDirectory(dir string) {
@@ -162,6 +245,10 @@ func (gi *GeoIP) GetName(ip string) (name string, netmask int) {
 		return
 	}
 
+	if rec, ok := gi.checkReserved(net.ParseIP(ip)); ok {
+		return rec.CountryName, rec.Netmask
+	}
+
 	gi.mu.Lock()
 	defer gi.mu.Unlock()
 
@@ -179,28 +266,20 @@ func (gi *GeoIP) GetName(ip string) (name string, netmask int) {
 	return
 }
 
-type GeoIPRecord struct {
-	CountryCode   string
-	CountryCode3  string
-	CountryName   string
-	Region        string
-	City          string
-	PostalCode    string
-	Latitude      float32
-	Longitude     float32
-	MetroCode     int
-	AreaCode      int
-	CharSet       int
-	ContinentCode string
-	Netmask       int
-}
+// GeoIPRecord and CityResult are aliases for the shared record package
+// types, kept under their original names here so existing callers of this
+// package don't have to change. They live in record, not here, so that
+// geoip/mmdb (and any other pure-Go backend) can return the same shapes
+// without pulling in cgo.
+type GeoIPRecord = record.Record
+type CityResult = record.CityResult
 
-// CityResult holds the result of looking up an IP in a City type database. The
-// lookup may be from either an IPv4 or IPv6 database.
-type CityResult struct {
-	Record  *GeoIPRecord
-	Netmask int
-}
+// Provider is the common set of lookups implemented by both GeoIP (this
+// package's cgo-based legacy libgeoip backend) and geoip/mmdb.MMDB (the
+// pure-Go MaxMind DB backend), letting callers pick a backend at runtime.
+type Provider = record.Provider
+
+var _ Provider = (*GeoIP)(nil)
 
 // LookupIPv4City looks up the IP in the database. The database must be an IPv4
 // City database.
@@ -226,10 +305,14 @@ func (gi *GeoIP) lookupIPv4City(ipString string) (CityResult, error) {
 		return CityResult{}, fmt.Errorf("database is not loaded")
 	}
 
+	// gi.db is only read under gi.mu: Watch/reload can free it otherwise.
+	gi.mu.Lock()
 	if gi.db.databaseType != GEOIP_CITY_EDITION_REV0 &&
 		gi.db.databaseType != GEOIP_CITY_EDITION_REV1 {
-		return CityResult{}, fmt.Errorf("invalid database type")
+		gi.mu.Unlock()
+		return CityResult{}, gi.wrongTypeErr()
 	}
+	gi.mu.Unlock()
 
 	ip := net.ParseIP(ipString)
 	if ip == nil {
@@ -241,22 +324,32 @@ func (gi *GeoIP) lookupIPv4City(ipString string) (CityResult, error) {
 		return CityResult{}, fmt.Errorf("IPv6 IP given for IPv4-only lookup")
 	}
 
+	if rec, ok := gi.checkReserved(ip); ok {
+		return CityResult{Record: rec, Netmask: rec.Netmask}, nil
+	}
+
 	cip := C.CString(ipString)
 	defer C.free(unsafe.Pointer(cip))
 
 	gi.mu.Lock()
 	record := C.GeoIP_record_by_addr(gi.db, cip)
 	netmask := int(gi.db.netmask)
-	gi.mu.Unlock()
 
 	if record == nil {
+		gi.mu.Unlock()
 		return CityResult{Netmask: netmask}, nil
 	}
 
-	defer C.GeoIPRecord_delete(record)
+	// convertRecord reads gi.db (for databaseType), so it has to happen
+	// before we unlock: Watch/reload can swap and free gi.db as soon as
+	// gi.mu is released, and we must not touch it after that.
+	rec := gi.convertRecord(record)
+	gi.mu.Unlock()
+
+	C.GeoIPRecord_delete(record)
 
 	return CityResult{
-		Record:  gi.convertRecord(record),
+		Record:  rec,
 		Netmask: netmask,
 	}, nil
 }
@@ -296,10 +389,14 @@ func (gi *GeoIP) LookupIPv6City(ipString string) (CityResult, error) {
 		return CityResult{}, fmt.Errorf("database is not loaded")
 	}
 
+	// gi.db is only read under gi.mu: Watch/reload can free it otherwise.
+	gi.mu.Lock()
 	if gi.db.databaseType != GEOIP_CITY_EDITION_REV0_V6 &&
 		gi.db.databaseType != GEOIP_CITY_EDITION_REV1_V6 {
-		return CityResult{}, fmt.Errorf("invalid database type")
+		gi.mu.Unlock()
+		return CityResult{}, gi.wrongTypeErr()
 	}
+	gi.mu.Unlock()
 
 	ip := net.ParseIP(ipString)
 	if ip == nil {
@@ -311,22 +408,32 @@ func (gi *GeoIP) LookupIPv6City(ipString string) (CityResult, error) {
 		return CityResult{}, fmt.Errorf("IPv4 IP given for IPv6-only lookup")
 	}
 
+	if rec, ok := gi.checkReserved(ip); ok {
+		return CityResult{Record: rec, Netmask: rec.Netmask}, nil
+	}
+
 	cip := C.CString(ipString)
 	defer C.free(unsafe.Pointer(cip))
 
 	gi.mu.Lock()
 	record := C.GeoIP_record_by_addr_v6(gi.db, cip)
 	netmask := int(gi.db.netmask)
-	gi.mu.Unlock()
 
 	if record == nil {
+		gi.mu.Unlock()
 		return CityResult{Netmask: netmask}, nil
 	}
 
-	defer C.GeoIPRecord_delete(record)
+	// See the comment in lookupIPv4City: convertRecord must run before we
+	// unlock, since it reads gi.db and Watch/reload can free it the moment
+	// gi.mu is released.
+	rec := gi.convertRecord(record)
+	gi.mu.Unlock()
+
+	C.GeoIPRecord_delete(record)
 
 	return CityResult{
-		Record:  gi.convertRecord(record),
+		Record:  rec,
 		Netmask: netmask,
 	}, nil
 }
@@ -338,6 +445,10 @@ func (gi *GeoIP) GetRegion(ip string) (string, string) {
 		return "", ""
 	}
 
+	if rec, ok := gi.checkReserved(net.ParseIP(ip)); ok {
+		return rec.CountryCode, ""
+	}
+
 	cip := C.CString(ip)
 	defer C.free(unsafe.Pointer(cip))
 
@@ -401,6 +512,10 @@ func (gi *GeoIP) GetNameV6(ip string) (name string, netmask int) {
 		return
 	}
 
+	if rec, ok := gi.checkReserved(net.ParseIP(ip)); ok {
+		return rec.CountryName, rec.Netmask
+	}
+
 	gi.mu.Lock()
 	defer gi.mu.Unlock()
 
@@ -425,6 +540,10 @@ func (gi *GeoIP) GetCountry(ip string) (cc string, netmask int) {
 		return
 	}
 
+	if rec, ok := gi.checkReserved(net.ParseIP(ip)); ok {
+		return rec.CountryCode, rec.Netmask
+	}
+
 	gi.mu.Lock()
 	defer gi.mu.Unlock()
 
@@ -447,6 +566,10 @@ func (gi *GeoIP) GetCountry_v6(ip string) (cc string, netmask int) {
 		return
 	}
 
+	if rec, ok := gi.checkReserved(net.ParseIP(ip)); ok {
+		return rec.CountryCode, rec.Netmask
+	}
+
 	gi.mu.Lock()
 	defer gi.mu.Unlock()
 
@@ -0,0 +1,141 @@
+package geoip
+
+/*
+#include <GeoIP.h>
+#include <GeoIPCity.h>
+*/
+import "C"
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// ipv4ToNum converts an IPv4 net.IP to the big-endian uint32 that
+// libgeoip's _by_ipnum entry points take, skipping the ParseIP/CString
+// round trip the string-based API needs.
+func ipv4ToNum(ip net.IP) (C.ulong, bool) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return 0, false
+	}
+	return C.ulong(binary.BigEndian.Uint32(ip4)), true
+}
+
+// GetCountryIP is the same as GetCountry but takes a net.IP, avoiding a
+// string round trip on the IPv4 hot path.
+func (gi *GeoIP) GetCountryIP(ip net.IP) (cc string, netmask int) {
+	if gi.db == nil {
+		return
+	}
+
+	if rec, ok := gi.checkReserved(ip); ok {
+		return rec.CountryCode, rec.Netmask
+	}
+
+	num, ok := ipv4ToNum(ip)
+	if !ok {
+		return gi.GetCountry(ip.String())
+	}
+
+	gi.mu.Lock()
+	defer gi.mu.Unlock()
+
+	ccountry := C.GeoIP_country_code_by_ipnum(gi.db, num)
+	if ccountry == nil {
+		return
+	}
+	cc = C.GoString(ccountry)
+	netmask = int(C.GeoIP_last_netmask(gi.db))
+	return
+}
+
+// LookupCityIP is the same as LookupIPv4City but takes a net.IP.
+func (gi *GeoIP) LookupCityIP(ip net.IP) (CityResult, error) {
+	if gi == nil || gi.db == nil {
+		return CityResult{}, fmt.Errorf("database is not loaded")
+	}
+
+	if rec, ok := gi.checkReserved(ip); ok {
+		return CityResult{Record: rec, Netmask: rec.Netmask}, nil
+	}
+
+	num, ok := ipv4ToNum(ip)
+	if !ok {
+		return gi.LookupIPv4City(ip.String())
+	}
+
+	gi.mu.Lock()
+	defer gi.mu.Unlock()
+
+	if gi.db.databaseType != GEOIP_CITY_EDITION_REV0 &&
+		gi.db.databaseType != GEOIP_CITY_EDITION_REV1 {
+		return CityResult{}, gi.wrongTypeErr()
+	}
+
+	return gi.lookupCityByNumLocked(num)
+}
+
+// lookupCityByNumLocked does the actual ipnum-based city lookup. Callers
+// must hold gi.mu.
+func (gi *GeoIP) lookupCityByNumLocked(num C.ulong) (CityResult, error) {
+	record := C.GeoIP_record_by_ipnum(gi.db, num)
+	netmask := int(gi.db.netmask)
+
+	if record == nil {
+		return CityResult{Netmask: netmask}, nil
+	}
+	defer C.GeoIPRecord_delete(record)
+
+	return CityResult{
+		Record:  gi.convertRecord(record),
+		Netmask: netmask,
+	}, nil
+}
+
+// LookupBatch looks up every entry in ips and writes the result to the
+// matching index in out, which must be at least len(ips) long. Unlike
+// calling LookupIPv4City/LookupCityIP in a loop, LookupBatch takes gi.mu
+// once for the whole batch, which is a significant win for log-processing
+// and analytics workloads that look up many IPs back to back.
+func (gi *GeoIP) LookupBatch(ips []net.IP, out []CityResult) error {
+	if gi == nil || gi.db == nil {
+		return fmt.Errorf("database is not loaded")
+	}
+	if len(out) < len(ips) {
+		return fmt.Errorf("out must be at least len(ips) long")
+	}
+
+	gi.mu.Lock()
+	defer gi.mu.Unlock()
+
+	if gi.db.databaseType != GEOIP_CITY_EDITION_REV0 &&
+		gi.db.databaseType != GEOIP_CITY_EDITION_REV1 {
+		return gi.wrongTypeErr()
+	}
+
+	for i, ip := range ips {
+		if rec, ok := gi.checkReservedLocked(ip); ok {
+			out[i] = CityResult{Record: rec, Netmask: rec.Netmask}
+			continue
+		}
+
+		num, ok := ipv4ToNum(ip)
+		if !ok {
+			// gi's database was already confirmed above to be an IPv4 City
+			// edition, so there's no way to resolve this one: report it
+			// rather than silently writing a zero-value "no match" result,
+			// the way LookupIPv4City/LookupCityIP would for the same IP.
+			return fmt.Errorf("entry %d (%s): IPv6 IP given for IPv4-only lookup", i, ip)
+		}
+
+		result, err := gi.lookupCityByNumLocked(num)
+		if err != nil {
+			return err
+		}
+		out[i] = result
+	}
+
+	return nil
+}
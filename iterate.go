@@ -0,0 +1,100 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"math/big"
+	"net"
+)
+
+// IterateIPv4City walks every prefix in an IPv4 City database, starting at
+// 0.0.0.0, calling fn once per prefix with the CIDR derived from the
+// netmask libgeoip reports alongside each lookup and the record for that
+// prefix. This is the standard technique for exporting a MaxMind database
+// to CSV/BIND reverse zones or for building an in-memory trie: the
+// database only ever tells you how big the current prefix is, never where
+// its boundaries are up front, so you have to walk it prefix by prefix.
+//
+// Iteration stops and returns fn's error as soon as fn returns one.
+func (gi *GeoIP) IterateIPv4City(fn func(cidr *net.IPNet, r *GeoIPRecord) error) error {
+	ip := uint32(0)
+	for {
+		result, err := gi.lookupIPv4City(ipv4FromUint32(ip))
+		if err != nil {
+			return err
+		}
+
+		netmask := result.Netmask
+		if netmask <= 0 || netmask > 32 {
+			netmask = 32
+		}
+
+		cidr := &net.IPNet{
+			IP:   ipv4Bytes(ip),
+			Mask: net.CIDRMask(netmask, 32),
+		}
+
+		if err := fn(cidr, result.Record); err != nil {
+			return err
+		}
+
+		size := uint64(1) << uint(32-netmask)
+		next := uint64(ip) + size
+		if next > 0xffffffff {
+			return nil
+		}
+		ip = uint32(next)
+	}
+}
+
+func ipv4Bytes(ip uint32) net.IP {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, ip)
+	return net.IP(b)
+}
+
+func ipv4FromUint32(ip uint32) string {
+	return ipv4Bytes(ip).String()
+}
+
+// IterateIPv6City is the same as IterateIPv4City but walks an IPv6 City
+// database starting at ::. Real IPv6 City databases are never dense enough
+// to visit every address in practice, but the address space is walked with
+// big.Int arithmetic for correctness regardless of prefix sizes returned.
+func (gi *GeoIP) IterateIPv6City(fn func(cidr *net.IPNet, r *GeoIPRecord) error) error {
+	ip := new(big.Int)
+	max := new(big.Int).Lsh(big.NewInt(1), 128)
+
+	for ip.Cmp(max) < 0 {
+		ipStr := ipv6FromBigInt(ip)
+		result, err := gi.LookupIPv6City(ipStr)
+		if err != nil {
+			return err
+		}
+
+		netmask := result.Netmask
+		if netmask <= 0 || netmask > 128 {
+			netmask = 128
+		}
+
+		cidr := &net.IPNet{
+			IP:   net.ParseIP(ipStr),
+			Mask: net.CIDRMask(netmask, 128),
+		}
+
+		if err := fn(cidr, result.Record); err != nil {
+			return err
+		}
+
+		size := new(big.Int).Lsh(big.NewInt(1), uint(128-netmask))
+		ip.Add(ip, size)
+	}
+
+	return nil
+}
+
+func ipv6FromBigInt(i *big.Int) string {
+	b := i.Bytes()
+	buf := make([]byte, 16)
+	copy(buf[16-len(b):], b)
+	return net.IP(buf).String()
+}
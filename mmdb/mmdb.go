@@ -0,0 +1,195 @@
+// Package mmdb is a pure-Go interface to MaxMind DB (GeoIP2/GeoLite2)
+// databases, for users who want to move off the deprecated libgeoip .dat
+// format without taking on cgo or the libgeoip system dependency the root
+// geoip package requires. It returns the same record.Record/CityResult
+// shapes as geoip, and implements record.Provider, so callers can switch
+// backends with minimal changes.
+package mmdb
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/horgh/geoip/record"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var _ record.Provider = (*MMDB)(nil)
+
+// MMDB wraps an open MaxMind DB (GeoIP2/GeoLite2) reader.
+type MMDB struct {
+	reader *maxminddb.Reader
+}
+
+// Open opens a MaxMind DB file, such as GeoLite2-City.mmdb or
+// GeoLite2-ASN.mmdb.
+func Open(file string) (*MMDB, error) {
+	reader, err := maxminddb.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("error opening MMDB database (%s): %s", file, err)
+	}
+	return &MMDB{reader: reader}, nil
+}
+
+// Close releases the underlying memory-mapped database file.
+func (m *MMDB) Close() error {
+	return m.reader.Close()
+}
+
+type cityRecord struct {
+	Country struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Subdivisions []struct {
+		IsoCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+	Postal struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"postal"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+		MetroCode int     `maxminddb:"metro_code"`
+	} `maxminddb:"location"`
+	Continent struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"continent"`
+}
+
+type asnRecord struct {
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// checkReserved reports whether ip falls in a reserved range and, if so,
+// returns a synthetic record for it. It uses the same default ranges and
+// matching logic as geoip.GeoIP, so switching backends doesn't silently
+// change how private/bogon IPs are handled.
+func checkReserved(ip net.IP) (*record.Record, bool) {
+	return record.MatchReserved(ip, nil, true)
+}
+
+// GetCountry returns the country code for ip and the netmask of the
+// matching prefix. It mirrors geoip.GeoIP.GetCountry so callers can switch
+// backends with minimal changes.
+func (m *MMDB) GetCountry(ipString string) (cc string, netmask int) {
+	ip := net.ParseIP(ipString)
+	if ip == nil {
+		return "", 0
+	}
+
+	if rec, ok := checkReserved(ip); ok {
+		return rec.CountryCode, rec.Netmask
+	}
+
+	var rec cityRecord
+	prefix, ok, err := m.reader.LookupNetwork(ip, &rec)
+	if err != nil || !ok {
+		return "", 0
+	}
+
+	ones, _ := prefix.Mask.Size()
+	return rec.Country.IsoCode, ones
+}
+
+// GetRecord returns the "City Record" for an IPv4 or IPv6 address, using
+// the same record.Record shape as geoip.GeoIP.GetRecord. Requires a City
+// database (GeoIP2-City or GeoLite2-City).
+func (m *MMDB) GetRecord(ipString string) *record.Record {
+	result, _ := m.lookupCity(ipString)
+	return result.Record
+}
+
+// LookupIPv4City looks up ipString in an MMDB City database. The database
+// must be an IPv4 or dual-stack City database.
+func (m *MMDB) LookupIPv4City(ipString string) (record.CityResult, error) {
+	ip := net.ParseIP(ipString)
+	if ip == nil {
+		return record.CityResult{}, fmt.Errorf("invalid IP address")
+	}
+	if ip.To4() == nil {
+		return record.CityResult{}, fmt.Errorf("IPv6 IP given for IPv4-only lookup")
+	}
+	return m.lookupCity(ipString)
+}
+
+// LookupIPv6City looks up ipString in an MMDB City database.
+func (m *MMDB) LookupIPv6City(ipString string) (record.CityResult, error) {
+	ip := net.ParseIP(ipString)
+	if ip == nil {
+		return record.CityResult{}, fmt.Errorf("invalid IP address")
+	}
+	if ip.To4() != nil {
+		return record.CityResult{}, fmt.Errorf("IPv4 IP given for IPv6-only lookup")
+	}
+	return m.lookupCity(ipString)
+}
+
+func (m *MMDB) lookupCity(ipString string) (record.CityResult, error) {
+	ip := net.ParseIP(ipString)
+	if ip == nil {
+		return record.CityResult{}, fmt.Errorf("invalid IP address")
+	}
+
+	if rec, ok := checkReserved(ip); ok {
+		return record.CityResult{Record: rec, Netmask: rec.Netmask}, nil
+	}
+
+	var rec cityRecord
+	prefix, ok, err := m.reader.LookupNetwork(ip, &rec)
+	if err != nil {
+		return record.CityResult{}, fmt.Errorf("error looking up %s: %s", ipString, err)
+	}
+	if !ok {
+		return record.CityResult{}, nil
+	}
+
+	ones, _ := prefix.Mask.Size()
+
+	region := ""
+	if len(rec.Subdivisions) > 0 {
+		region = rec.Subdivisions[0].IsoCode
+	}
+
+	rr := &record.Record{
+		CountryCode:   rec.Country.IsoCode,
+		CountryName:   rec.Country.Names["en"],
+		Region:        region,
+		City:          rec.City.Names["en"],
+		PostalCode:    rec.Postal.Code,
+		Latitude:      float32(rec.Location.Latitude),
+		Longitude:     float32(rec.Location.Longitude),
+		MetroCode:     rec.Location.MetroCode,
+		ContinentCode: rec.Continent.Code,
+		Netmask:       ones,
+	}
+
+	return record.CityResult{Record: rr, Netmask: ones}, nil
+}
+
+// GetName returns the autonomous system organization name for ip and the
+// netmask of the matching prefix. Requires a GeoIP2-ASN or GeoLite2-ASN
+// database.
+func (m *MMDB) GetName(ipString string) (name string, netmask int) {
+	ip := net.ParseIP(ipString)
+	if ip == nil {
+		return "", 0
+	}
+
+	if rec, ok := checkReserved(ip); ok {
+		return rec.CountryName, rec.Netmask
+	}
+
+	var rec asnRecord
+	prefix, ok, err := m.reader.LookupNetwork(ip, &rec)
+	if err != nil || !ok {
+		return "", 0
+	}
+
+	ones, _ := prefix.Mask.Size()
+	return rec.AutonomousSystemOrganization, ones
+}
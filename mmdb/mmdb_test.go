@@ -0,0 +1,24 @@
+package mmdb
+
+import (
+	"net"
+	"testing"
+)
+
+// checkReserved is exercised directly here because it's the only piece of
+// MMDB that doesn't need an actual .mmdb file open: the LookupNetwork paths
+// in GetCountry/lookupCity/GetName need a real MaxMind DB fixture to cover,
+// which this repo doesn't vendor.
+func TestCheckReserved(t *testing.T) {
+	rec, ok := checkReserved(net.ParseIP("192.168.1.1"))
+	if !ok {
+		t.Fatal("expected 192.168.1.1 to match a reserved range")
+	}
+	if rec.CountryCode != "RD" {
+		t.Errorf("CountryCode = %q, want %q", rec.CountryCode, "RD")
+	}
+
+	if _, ok := checkReserved(net.ParseIP("8.8.8.8")); ok {
+		t.Error("expected 8.8.8.8 not to match a reserved range")
+	}
+}
@@ -0,0 +1,42 @@
+// Package record holds the data types shared across geoip's backends (the
+// cgo-based legacy libgeoip wrapper in the root package and the pure-Go
+// MMDB reader in geoip/mmdb). Keeping them here, free of cgo, means a
+// consumer that only wants the MMDB backend never has to pull in cgo or
+// libgeoip to get the shapes it returns.
+package record
+
+// Record holds a "City Record" style geolocation result. It's returned by
+// every backend's city/record lookups.
+type Record struct {
+	CountryCode   string
+	CountryCode3  string
+	CountryName   string
+	Region        string
+	City          string
+	PostalCode    string
+	Latitude      float32
+	Longitude     float32
+	MetroCode     int
+	AreaCode      int
+	CharSet       int
+	ContinentCode string
+	Netmask       int
+}
+
+// CityResult holds the result of looking up an IP in a City type database.
+// The lookup may be from either an IPv4 or IPv6 database.
+type CityResult struct {
+	Record  *Record
+	Netmask int
+}
+
+// Provider is the common set of lookups implemented by every geoip
+// backend, letting callers pick one at runtime without changing call
+// sites.
+type Provider interface {
+	GetCountry(ip string) (cc string, netmask int)
+	GetRecord(ip string) *Record
+	GetName(ip string) (name string, netmask int)
+	LookupIPv4City(ip string) (CityResult, error)
+	LookupIPv6City(ip string) (CityResult, error)
+}
@@ -0,0 +1,100 @@
+package record
+
+import "net"
+
+// ReservedCountryCode and ReservedCountryName are returned for IPs that
+// match a reserved range instead of querying a database, matching the
+// convention other GeoIP wrappers use to flag private-range IPs explicitly
+// rather than returning an empty/unknown result.
+const (
+	ReservedCountryCode = "RD"
+	ReservedCountryName = "Reserved"
+)
+
+// defaultReservedRangeCIDRs is IANA's special-purpose address registries:
+// RFC1918 private space, loopback, link-local, CGNAT, documentation
+// ranges, Teredo, and IPv6 ULA space.
+var defaultReservedRangeCIDRs = []string{
+	// IPv4
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10", // CGNAT
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24", // documentation (TEST-NET-1)
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24", // documentation (TEST-NET-2)
+	"203.0.113.0/24",  // documentation (TEST-NET-3)
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	// IPv6
+	"::1/128",
+	"::/128",
+	"64:ff9b::/96",  // NAT64
+	"100::/64",      // discard-only
+	"2001::/32",     // Teredo
+	"2001:db8::/32", // documentation
+	"fc00::/7",      // unique local (ULA)
+	"fe80::/10",     // link-local
+	"ff00::/8",      // multicast
+}
+
+// defaultReservedRanges is parsed from defaultReservedRangeCIDRs once at
+// package init rather than on every MatchReserved call: it's on the hot
+// path of every Get*/Lookup* call that doesn't set custom ranges, including
+// inside LookupBatch's per-entry loop, so re-running ParseCIDR over the
+// whole list each time would undo the very per-call overhead LookupBatch
+// exists to eliminate.
+var defaultReservedRanges = parseReservedRangeCIDRs(defaultReservedRangeCIDRs)
+
+func parseReservedRangeCIDRs(cidrs []string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			// Only reachable if a constant above is malformed.
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// DefaultReservedRanges returns IANA's special-purpose address registries:
+// RFC1918 private space, loopback, link-local, CGNAT, documentation
+// ranges, Teredo, and IPv6 ULA space. The list is parsed once at package
+// init; callers must not mutate the returned slice or its entries.
+func DefaultReservedRanges() []*net.IPNet {
+	return defaultReservedRanges
+}
+
+// MatchReserved reports whether ip falls in one of ranges and, if so,
+// returns a synthetic Record for it so callers never get a meaningless
+// "unknown" result for private-range traffic. If useDefault is true, ranges
+// is ignored in favor of DefaultReservedRanges(); backends share this
+// function so that switching between them doesn't silently change how
+// reserved IPs are handled.
+func MatchReserved(ip net.IP, ranges []*net.IPNet, useDefault bool) (*Record, bool) {
+	if ip == nil {
+		return nil, false
+	}
+
+	if useDefault {
+		ranges = DefaultReservedRanges()
+	}
+
+	for _, r := range ranges {
+		if r.Contains(ip) {
+			ones, _ := r.Mask.Size()
+			return &Record{
+				CountryCode: ReservedCountryCode,
+				CountryName: ReservedCountryName,
+				Netmask:     ones,
+			}, true
+		}
+	}
+	return nil, false
+}
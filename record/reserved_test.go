@@ -0,0 +1,97 @@
+package record
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMatchReserved(t *testing.T) {
+	cases := []struct {
+		name        string
+		ip          string
+		ranges      []*net.IPNet
+		useDefault  bool
+		wantMatch   bool
+		wantNetmask int
+	}{
+		{
+			name:        "default ranges match RFC1918",
+			ip:          "10.1.2.3",
+			useDefault:  true,
+			wantMatch:   true,
+			wantNetmask: 8,
+		},
+		{
+			name:        "default ranges match IPv6 ULA",
+			ip:          "fc00::1",
+			useDefault:  true,
+			wantMatch:   true,
+			wantNetmask: 7,
+		},
+		{
+			name:       "default ranges don't match a public IP",
+			ip:         "8.8.8.8",
+			useDefault: true,
+			wantMatch:  false,
+		},
+		{
+			name:        "custom ranges override the default list",
+			ip:          "8.8.8.0",
+			ranges:      mustParseCIDRs(t, "8.8.8.0/24"),
+			wantMatch:   true,
+			wantNetmask: 24,
+		},
+		{
+			name:      "custom ranges don't fall back to the default list",
+			ip:        "10.1.2.3",
+			ranges:    mustParseCIDRs(t, "8.8.8.0/24"),
+			wantMatch: false,
+		},
+		{
+			name:       "nil IP never matches",
+			ip:         "",
+			useDefault: true,
+			wantMatch:  false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var ip net.IP
+			if c.ip != "" {
+				ip = net.ParseIP(c.ip)
+			}
+
+			rec, ok := MatchReserved(ip, c.ranges, c.useDefault)
+			if ok != c.wantMatch {
+				t.Fatalf("MatchReserved(%s) ok = %v, want %v", c.ip, ok, c.wantMatch)
+			}
+			if !c.wantMatch {
+				return
+			}
+
+			if rec.CountryCode != ReservedCountryCode {
+				t.Errorf("CountryCode = %q, want %q", rec.CountryCode, ReservedCountryCode)
+			}
+			if rec.CountryName != ReservedCountryName {
+				t.Errorf("CountryName = %q, want %q", rec.CountryName, ReservedCountryName)
+			}
+			if rec.Netmask != c.wantNetmask {
+				t.Errorf("Netmask = %d, want %d", rec.Netmask, c.wantNetmask)
+			}
+		})
+	}
+}
+
+func mustParseCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	t.Helper()
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("ParseCIDR(%q): %s", cidr, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
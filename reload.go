@@ -0,0 +1,154 @@
+package geoip
+
+/*
+#include <GeoIP.h>
+*/
+import "C"
+
+import (
+	"os"
+	"runtime"
+	"time"
+)
+
+// ReloadFunc is called after a watcher-triggered reload attempt, whether it
+// succeeded or not. err is nil on success. Callers can use it to log
+// reloads or bump metrics.
+type ReloadFunc func(err error)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// OpenWithReload opens files as Open does and starts a background watcher
+// (see Watch) that reopens the database whenever one of files changes on
+// disk. Call Close to stop the watcher and release the database.
+func OpenWithReload(interval time.Duration, onReload ReloadFunc, files ...string) (*GeoIP, error) {
+	g, err := Open(files...)
+	if err != nil {
+		return nil, err
+	}
+	g.Watch(interval, onReload)
+	return g, nil
+}
+
+// Watch starts a background goroutine that periodically stats the
+// database files gi was opened with and, if any of their mtime or size has
+// changed, reopens them and atomically swaps the new handle in under
+// gi.mu, deleting the old handle once it's no longer referenced. onReload,
+// if non-nil, is called after every reload attempt, including failed ones,
+// in which case the previous database stays in use.
+//
+// Watch does nothing if gi wasn't opened from a file (for example, via
+// OpenType) or if it's already watching.
+func (gi *GeoIP) Watch(interval time.Duration, onReload ReloadFunc) {
+	if len(gi.files) == 0 {
+		return
+	}
+
+	gi.watchMu.Lock()
+	defer gi.watchMu.Unlock()
+	if gi.watchStop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	gi.watchStop = stop
+
+	states := statFiles(gi.files)
+
+	gi.watchWG.Add(1)
+	go func() {
+		defer gi.watchWG.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				next := statFiles(gi.files)
+				if statesEqual(states, next) {
+					continue
+				}
+				states = next
+
+				err := gi.reload()
+				if onReload != nil {
+					onReload(err)
+				}
+			}
+		}
+	}()
+}
+
+func statFiles(files []string) []fileState {
+	states := make([]fileState, len(files))
+	for i, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		states[i] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return states
+}
+
+func statesEqual(a, b []fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// reload opens a fresh database from gi.files and swaps it in for the
+// current one. The old handle is deleted before gi.mu is released, not
+// after: every lookup that touches gi.db (including the part of
+// convertRecord that reads gi.db after its own C call returns) does so
+// while holding gi.mu, so holding the lock across the delete is what
+// actually guarantees no in-flight lookup can still be using the old
+// handle when it's freed.
+func (gi *GeoIP) reload() error {
+	newGi, err := OpenDb(gi.files, GEOIP_MEMORY_CACHE)
+	if err != nil {
+		return err
+	}
+
+	gi.mu.Lock()
+	old := gi.db
+	gi.db = newGi.db
+	C.GeoIP_delete(old)
+	gi.mu.Unlock()
+
+	// newGi's finalizer would otherwise also try to delete the handle we
+	// just adopted into gi and already freed above.
+	newGi.db = nil
+	runtime.SetFinalizer(newGi, nil)
+
+	return nil
+}
+
+// Close stops any running watcher and releases the underlying database
+// handle. gi must not be used after calling Close.
+func (gi *GeoIP) Close() {
+	gi.watchMu.Lock()
+	stop := gi.watchStop
+	gi.watchStop = nil
+	gi.watchMu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		gi.watchWG.Wait()
+	}
+
+	runtime.SetFinalizer(gi, nil)
+	gi.free()
+}
@@ -0,0 +1,37 @@
+package geoip
+
+import (
+	"net"
+
+	"github.com/horgh/geoip/record"
+)
+
+// SetReservedRanges replaces the set of ranges gi treats as reserved. Pass
+// nil (or an empty slice) to disable the reserved-range short-circuit
+// entirely. The default, used if SetReservedRanges is never called, covers
+// IANA's special-purpose address registries and is shared with
+// geoip/mmdb.MMDB, so the two backends agree on how private/bogon IPs are
+// handled unless this is called.
+func (gi *GeoIP) SetReservedRanges(ranges []*net.IPNet) {
+	gi.mu.Lock()
+	defer gi.mu.Unlock()
+	gi.reservedRanges = ranges
+	gi.reservedSet = true
+}
+
+// checkReserved reports whether ip falls in one of gi's reserved ranges
+// and, if so, returns a synthetic record for it so callers never get a
+// meaningless "unknown" result for private-range traffic.
+func (gi *GeoIP) checkReserved(ip net.IP) (*GeoIPRecord, bool) {
+	gi.mu.Lock()
+	ranges, set := gi.reservedRanges, gi.reservedSet
+	gi.mu.Unlock()
+
+	return record.MatchReserved(ip, ranges, !set)
+}
+
+// checkReservedLocked is the same as checkReserved but for callers that
+// already hold gi.mu, such as LookupBatch.
+func (gi *GeoIP) checkReservedLocked(ip net.IP) (*GeoIPRecord, bool) {
+	return record.MatchReserved(ip, gi.reservedRanges, !gi.reservedSet)
+}